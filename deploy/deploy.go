@@ -0,0 +1,60 @@
+// Package deploy provides prebuilt ghooks handlers for the common case of
+// triggering a deploy from a "push" webhook: pulling the updated branch and
+// running a build/restart script, with output captured and lifecycle events
+// exposed for monitoring.
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// Step is a single unit of deploy work. It writes its combined stdout/stderr
+// to out as it runs, and returns an error if the step failed.
+type Step func(ctx context.Context, out io.Writer) error
+
+// GitPull returns a Step that runs "git pull origin <branch>" in repoPath,
+// fetching branch and merging it into the current checkout.
+func GitPull(repoPath, branch string) Step {
+	return func(ctx context.Context, out io.Writer) error {
+		cmd := exec.CommandContext(ctx, "git", "pull", "origin", branch)
+		cmd.Dir = repoPath
+		cmd.Stdout = out
+		cmd.Stderr = out
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("git pull origin %s: %w", branch, err)
+		}
+		return nil
+	}
+}
+
+// RunScript returns a Step that runs the executable at path with env
+// appended to the current process's environment, e.g. "FOO=bar".
+func RunScript(path string, env ...string) Step {
+	return func(ctx context.Context, out io.Writer) error {
+		cmd := exec.CommandContext(ctx, path)
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Stdout = out
+		cmd.Stderr = out
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("run %s: %w", path, err)
+		}
+		return nil
+	}
+}
+
+// Compose returns a Step that runs steps in order, stopping at the first
+// one that returns an error.
+func Compose(steps ...Step) Step {
+	return func(ctx context.Context, out io.Writer) error {
+		for _, step := range steps {
+			if err := step(ctx, out); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
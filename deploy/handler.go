@@ -0,0 +1,65 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/erikh/ghooks"
+)
+
+// PushHandler returns a ghooks handler (suitable for Server.On("push", ...))
+// that runs step against repoPath whenever the push payload's ref matches
+// branch, ignoring pushes to any other branch.
+func PushHandler(m *Manager, repoPath, branch string, step Step) func(ctx context.Context, event ghooks.PushEvent) error {
+	ref := "refs/heads/" + branch
+	return func(ctx context.Context, event ghooks.PushEvent) error {
+		if event.Ref != ref {
+			return nil
+		}
+		m.Deploy(ctx, repoPath, step)
+		return nil
+	}
+}
+
+// deploymentView is the JSON representation HTTPHandler serves for a
+// Deployment.
+type deploymentView struct {
+	ID     string `json:"id"`
+	Repo   string `json:"repo"`
+	Phase  Phase  `json:"phase"`
+	Error  string `json:"error,omitempty"`
+	Output string `json:"output"`
+}
+
+// HTTPHandler serves deployment status and captured output at
+// /deploys/<id>, as registered under prefix (e.g. "/deploys/").
+func HTTPHandler(m *Manager, prefix string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := strings.TrimPrefix(req.URL.Path, prefix)
+		if id == "" {
+			http.NotFound(w, req)
+			return
+		}
+
+		d, ok := m.Get(id)
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+
+		view := deploymentView{
+			ID:     d.ID,
+			Repo:   d.Repo,
+			Phase:  d.Status(),
+			Output: string(d.Output()),
+		}
+		if err := d.Result(); err != nil {
+			view.Error = err.Error()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(view)
+	})
+}
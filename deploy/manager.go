@@ -0,0 +1,176 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Phase is a deployment's lifecycle state.
+type Phase string
+
+const (
+	// Started is emitted once a deployment begins running its Step.
+	Started Phase = "started"
+	// Succeeded is emitted once a deployment's Step returns nil.
+	Succeeded Phase = "succeeded"
+	// Failed is emitted once a deployment's Step returns an error.
+	Failed Phase = "failed"
+)
+
+// defaultBufferSize bounds how much captured output a Deployment retains
+// when Manager isn't told otherwise.
+const defaultBufferSize = 64 * 1024
+
+// Event is published to a Manager's subscribers as a deployment moves
+// through its lifecycle.
+type Event struct {
+	Repo       string
+	Deployment *Deployment
+	Phase      Phase
+	Err        error
+}
+
+// Deployment is a single, in-flight or completed run of a Step. Its phase
+// and error are updated from the deploy goroutine as the Step runs; read
+// them with Status and Result.
+type Deployment struct {
+	ID   string
+	Repo string
+
+	mu     sync.Mutex
+	phase  Phase
+	err    error
+	output *ringBuffer
+}
+
+// Status returns the deployment's current phase.
+func (d *Deployment) Status() Phase {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.phase
+}
+
+// Result returns the error the deployment's Step returned, if it has
+// finished and failed.
+func (d *Deployment) Result() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+func (d *Deployment) setPhase(phase Phase, err error) {
+	d.mu.Lock()
+	d.phase = phase
+	d.err = err
+	d.mu.Unlock()
+}
+
+// Output returns the deployment's captured combined stdout/stderr, up to
+// the Manager's BufferSize most recent bytes.
+func (d *Deployment) Output() []byte {
+	return d.output.Bytes()
+}
+
+// Manager runs Steps as deployments, serializing concurrent deployments to
+// the same repo and keeping their state and output available by ID.
+type Manager struct {
+	// BufferSize bounds how many bytes of output each deployment retains.
+	// 0 defaults to 64KB.
+	BufferSize int
+
+	mu          sync.Mutex
+	repoLocks   map[string]*sync.Mutex
+	deployments map[string]*Deployment
+	subscribers []func(Event)
+	nextID      uint64
+}
+
+// NewManager creates a *Manager ready to run deployments.
+func NewManager() *Manager {
+	return &Manager{
+		repoLocks:   make(map[string]*sync.Mutex),
+		deployments: make(map[string]*Deployment),
+	}
+}
+
+// Subscribe registers fn to be called, in order, for every lifecycle event
+// across every repo this Manager deploys.
+func (m *Manager) Subscribe(fn func(Event)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// Get returns the deployment with the given ID, if it exists.
+func (m *Manager) Get(id string) (*Deployment, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.deployments[id]
+	return d, ok
+}
+
+func (m *Manager) repoLock(repo string) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, ok := m.repoLocks[repo]
+	if !ok {
+		l = &sync.Mutex{}
+		m.repoLocks[repo] = l
+	}
+	return l
+}
+
+func (m *Manager) bufferSize() int {
+	if m.BufferSize > 0 {
+		return m.BufferSize
+	}
+	return defaultBufferSize
+}
+
+func (m *Manager) emit(repo string, d *Deployment, phase Phase, err error) {
+	m.mu.Lock()
+	subscribers := append([]func(Event){}, m.subscribers...)
+	m.mu.Unlock()
+
+	event := Event{Repo: repo, Deployment: d, Phase: phase, Err: err}
+	for _, fn := range subscribers {
+		fn(event)
+	}
+}
+
+// Deploy registers and starts a deployment running step against repo,
+// serialized against any other deployment already running for the same
+// repo, and returns immediately with the new *Deployment. Its Phase and
+// Output update as the Step runs; subscribe via Subscribe to be notified of
+// the Started, Succeeded and Failed transitions.
+func (m *Manager) Deploy(ctx context.Context, repo string, step Step) *Deployment {
+	m.mu.Lock()
+	m.nextID++
+	id := fmt.Sprintf("%s-%d", repo, m.nextID)
+	d := &Deployment{ID: id, Repo: repo, output: newRingBuffer(m.bufferSize())}
+	m.deployments[id] = d
+	m.mu.Unlock()
+
+	lock := m.repoLock(repo)
+
+	go func() {
+		lock.Lock()
+		defer lock.Unlock()
+
+		d.setPhase(Started, nil)
+		m.emit(repo, d, Started, nil)
+
+		err := step(ctx, d.output)
+
+		if err != nil {
+			d.setPhase(Failed, err)
+			m.emit(repo, d, Failed, err)
+			return
+		}
+		d.setPhase(Succeeded, nil)
+		m.emit(repo, d, Succeeded, nil)
+	}()
+
+	return d
+}
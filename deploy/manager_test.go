@@ -0,0 +1,141 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestManagerSerializesDeploysPerRepo(t *testing.T) {
+	m := NewManager()
+
+	var running int32
+	var sawConcurrent bool
+	var mu sync.Mutex
+
+	step := Step(func(ctx context.Context, out io.Writer) error {
+		if atomic.AddInt32(&running, 1) > 1 {
+			mu.Lock()
+			sawConcurrent = true
+			mu.Unlock()
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	deployments := make([]*Deployment, 5)
+	for i := range deployments {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deployments[i] = m.Deploy(context.Background(), "repo-a", step)
+		}()
+	}
+	wg.Wait()
+
+	deadline := time.After(time.Second)
+	for _, d := range deployments {
+		for d.Status() != Succeeded && d.Status() != Failed {
+			select {
+			case <-deadline:
+				t.Fatal("timed out waiting for deployments to finish")
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if sawConcurrent {
+		t.Fatal("expected deploys for the same repo to be serialized, but two ran concurrently")
+	}
+}
+
+func TestManagerConcurrentStatusReadsDuringDeploy(t *testing.T) {
+	m := NewManager()
+
+	release := make(chan struct{})
+	step := Step(func(ctx context.Context, out io.Writer) error {
+		out.Write([]byte("working"))
+		<-release
+		return errors.New("boom")
+	})
+
+	d := m.Deploy(context.Background(), "repo-b", step)
+
+	// Concurrently poll Status/Result/Output while the deploy is in flight,
+	// the way HTTPHandler would; run under `go test -race` to catch any
+	// unsynchronized access to Deployment's mutable fields.
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = d.Status()
+				_ = d.Result()
+				_ = d.Output()
+			}
+		}
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	deadline := time.After(time.Second)
+	for d.Status() != Failed {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for deployment to fail")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if d.Result() == nil {
+		t.Fatal("expected Result to report the step's error")
+	}
+}
+
+func TestHTTPHandlerServesDeploymentStatus(t *testing.T) {
+	m := NewManager()
+	d := m.Deploy(context.Background(), "repo-c", func(ctx context.Context, out io.Writer) error {
+		out.Write([]byte("done"))
+		return nil
+	})
+
+	deadline := time.After(time.Second)
+	for d.Status() != Succeeded {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for deployment to succeed")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	h := HTTPHandler(m, "/deploys/")
+	req := httptest.NewRequest("GET", "/deploys/"+d.ID, nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"phase":"succeeded"`) {
+		t.Fatalf("expected response to report phase succeeded, got %s", w.Body.String())
+	}
+}
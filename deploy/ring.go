@@ -0,0 +1,40 @@
+package deploy
+
+import "sync"
+
+// ringBuffer is a fixed-capacity byte buffer that keeps only the most
+// recently written bytes, so a long-running deploy can't grow its captured
+// output without bound.
+type ringBuffer struct {
+	mu   sync.Mutex
+	buf  []byte
+	cap  int
+	full bool
+}
+
+func newRingBuffer(capacity int) *ringBuffer {
+	return &ringBuffer{cap: capacity}
+}
+
+// Write implements io.Writer.
+func (r *ringBuffer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buf = append(r.buf, p...)
+	if len(r.buf) > r.cap {
+		r.buf = r.buf[len(r.buf)-r.cap:]
+		r.full = true
+	}
+	return len(p), nil
+}
+
+// Bytes returns a copy of the buffered output.
+func (r *ringBuffer) Bytes() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]byte, len(r.buf))
+	copy(out, r.buf)
+	return out
+}
@@ -0,0 +1,61 @@
+package ghooks
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Regression test for a panic in reflect-based dispatch: a "null" JSON body
+// decodes to a nil interface{}, and reflect.ValueOf(nil) is the zero
+// reflect.Value, which reflect.Call used to reject.
+func TestHandlerNilJSONBodyDoesNotPanic(t *testing.T) {
+	s := NewServer()
+
+	s.On("push", func(ctx context.Context, payload interface{}) error {
+		if payload != nil {
+			t.Fatalf("expected nil payload for a null body, got %#v", payload)
+		}
+		return nil
+	})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("null"))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	s.Handler(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
+
+// TestHandlerDecodesTypedPayload covers the other half of On's reflection
+// dispatch: a handler registered with a typed payload struct gets the body
+// decoded into that struct, instead of the raw map[string]interface{}.
+func TestHandlerDecodesTypedPayload(t *testing.T) {
+	s := NewServer()
+
+	var got PushEvent
+	s.On("push", func(ctx context.Context, event PushEvent) error {
+		got = event
+		return nil
+	})
+
+	body := `{"ref":"refs/heads/main","before":"aaa","after":"bbb","repository":{"full_name":"erikh/ghooks"},"sender":{"login":"erikh"}}`
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	s.Handler(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got.Ref != "refs/heads/main" || got.Repository.FullName != "erikh/ghooks" || got.Sender.Login != "erikh" {
+		t.Fatalf("expected body decoded into PushEvent, got %#v", got)
+	}
+}
@@ -0,0 +1,15 @@
+package ghooks
+
+// TransientError wraps a handler error to mark it as worth retrying.
+// Handlers registered with Server.On should return one to have Server.invoke
+// retry the call, with exponential backoff, up to Server.MaxRetries times
+// before giving up and reporting to Server.ErrorHandler.
+type TransientError struct {
+	Err error
+}
+
+// Error implements error.
+func (e *TransientError) Error() string { return e.Err.Error() }
+
+// Unwrap lets errors.As/errors.Is see through to the wrapped error.
+func (e *TransientError) Unwrap() error { return e.Err }
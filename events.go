@@ -0,0 +1,72 @@
+package ghooks
+
+// This file contains the typed payload structs On's reflection-based
+// dispatch decodes webhook bodies into (see Server.runHandlers). Only the
+// fields commonly needed by handlers are modeled; handlers that need the
+// full payload can still register with
+// `func(ctx context.Context, payload interface{}) error` and get the raw
+// decoded JSON.
+
+// Repository is the repository a webhook event occurred against.
+type Repository struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Private  bool   `json:"private"`
+}
+
+// User is a GitHub user or organization referenced by an event.
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+// Commit is a single commit as reported by a PushEvent.
+type Commit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	Author  User   `json:"author"`
+}
+
+// PushEvent is the payload of a "push" event.
+type PushEvent struct {
+	Ref        string     `json:"ref"`
+	Before     string     `json:"before"`
+	After      string     `json:"after"`
+	Commits    []Commit   `json:"commits"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
+
+// PullRequest is the pull request referenced by a PullRequestEvent.
+type PullRequest struct {
+	Number int    `json:"number"`
+	State  string `json:"state"`
+	Title  string `json:"title"`
+	User   User   `json:"user"`
+}
+
+// PullRequestEvent is the payload of a "pull_request" event.
+type PullRequestEvent struct {
+	Action      string      `json:"action"`
+	Number      int         `json:"number"`
+	PullRequest PullRequest `json:"pull_request"`
+	Repository  Repository  `json:"repository"`
+	Sender      User        `json:"sender"`
+}
+
+// IssueCommentEvent is the payload of an "issue_comment" event.
+type IssueCommentEvent struct {
+	Action string `json:"action"`
+	Issue  struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+	} `json:"issue"`
+	Comment struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+		User User   `json:"user"`
+	} `json:"comment"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+}
@@ -13,6 +13,7 @@ Usage
 		package main
 
 		import (
+				"context"
 				"fmt"
 				"log"
 
@@ -29,31 +30,99 @@ Usage
 				hooks.Run()
 		}
 
-		func pushHandler(payload interface{}) {
+		func pushHandler(ctx context.Context, payload interface{}) error {
 				fmt.Println("puuuuush")
+				return nil
 		}
 
-		func pullRequestHandler(payload interface{}) {
+		func pullRequestHandler(ctx context.Context, payload interface{}) error {
 				fmt.Println("pull_request")
+				return nil
 		}
 
 After starting this server:
 
 		curl -H "X-GitHub-Event: push" -d '{"hoge":"fuga"}' http://localhost:8080
 		> puuuuush
+
+Handlers may also take one of the typed payload structs (PushEvent,
+PullRequestEvent, IssueCommentEvent, ...) instead of interface{}, in which
+case the raw body is decoded into that struct before the handler runs:
+
+		hooks.On("push", func(ctx context.Context, event ghooks.PushEvent) error {
+				fmt.Println(event.Repository.FullName)
+				return nil
+		})
+
+Server.Provider controls which forge's headers are read to find the event
+name; it defaults to GitHubProvider, with GitLabProvider, BitbucketProvider
+and GiteaProvider also available for multiplexing other forges onto the
+same server.
+
+Handlers are scoped to the *Server they were registered on, so a process
+can run several servers without their handlers seeing each other's events.
+Set Server.DedupSize (and optionally Server.DedupTTL) to have repeat
+deliveries, as identified by the provider's delivery header, short-circuited
+with 200 OK instead of re-invoked.
+
+Server.Secrets accepts one or more HMAC secrets, and a request is accepted
+if it validates against any of them, which lets operators roll a secret
+without downtime. Signatures are read from X-Hub-Signature-256 or
+X-Hub-Signature-512 when present, falling back to the legacy
+X-Hub-Signature (sha1) header.
+
+Handlers accept a context.Context alongside the payload and return an
+error:
+
+		hooks.On("push", func(ctx context.Context, event ghooks.PushEvent) error {
+				fmt.Println(event.Repository.FullName)
+				return nil
+		})
+
+By default Handler runs handlers synchronously and waits for them before
+responding, same as before. Set Server.Workers (and optionally
+Server.QueueSize) to hand events off to a bounded worker pool instead:
+Handler then returns 202 Accepted once the event is queued, or 503 Service
+Unavailable if the queue is full, so a slow handler no longer blocks the
+request or risks GitHub's delivery timeout. Set Server.RunSync to force
+synchronous delivery even with workers configured. Errors returned by
+handlers go to Server.ErrorHandler; return a *TransientError to have the
+failure retried with exponential backoff, up to Server.MaxRetries times.
+
+Callers who want to compose ghooks with ordinary net/http middleware
+(logging, metrics, tracing, rate-limiting) instead of using Server can use
+Router, an http.Handler that dispatches per event name:
+
+		r := ghooks.NewRouter()
+		r.HandleFunc("push", pushHTTPHandler)
+		r.Authorize("pull_request", http.HandlerFunc(prHandler), secret)
+		http.ListenAndServe(":2222", r)
+
+Authorize wraps any http.Handler with ghooks' signature validation, and
+Router.NotFoundHandler / Router.UnauthorizedHandler let callers customize
+the responses to an unrecognized event or a failed signature check.
+
+The github.com/erikh/ghooks/deploy subpackage ships a ready-made push-to-deploy
+handler: deploy.PushHandler runs a deploy Step (for example
+deploy.Compose(deploy.GitPull(...), deploy.RunScript(...))) whenever it
+receives a "push" event for a matching branch, serializing concurrent
+deploys per repo, and deploy.HTTPHandler exposes each deployment's captured
+output over HTTP.
 */
 package ghooks
 
 import (
 	"bytes"
-	"crypto/hmac"
-	"crypto/sha1"
-	"encoding/hex"
+	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -63,38 +132,159 @@ const (
 
 // Server is the hander pipeline for serving github hooks. One can instantiate a server
 type Server struct {
+	// Secret is kept for backward compatibility; prefer Secrets.
 	Secret string
+	// Secrets is the set of HMAC secrets a request's signature may be
+	// validated against, accepted if any one of them matches. This lets
+	// operators roll keys by adding the new secret before removing the
+	// old one.
+	Secrets  []string
+	Provider Provider
+
+	// DedupSize is the number of deliveries to remember for the purpose of
+	// discarding repeats. 0 (the default) disables deduplication.
+	DedupSize int
+
+	// DedupTTL is how long a remembered delivery counts as a repeat. 0
+	// means deliveries never expire on their own, only by LRU eviction
+	// once DedupSize is exceeded.
+	DedupTTL time.Duration
+
+	// Workers is the number of goroutines draining the event queue. 0
+	// (the default) keeps Handler synchronous: it runs handlers inline
+	// and waits for them before responding, as before.
+	Workers int
+
+	// QueueSize bounds how many queued events Handler will hold before
+	// returning 503 Service Unavailable. 0 defaults to Workers.
+	QueueSize int
+
+	// RunSync forces synchronous delivery even when Workers is set,
+	// restoring the pre-worker-pool behavior for callers that still want
+	// to wait for handlers before responding.
+	RunSync bool
+
+	// ErrorHandler, if set, is called with the error returned by a
+	// handler once retries (see MaxRetries) are exhausted.
+	ErrorHandler func(event string, err error)
+
+	// MaxRetries is how many additional times a handler is called after
+	// it returns a *TransientError, with exponential backoff between
+	// attempts starting at RetryBaseDelay. 0 disables retries.
+	MaxRetries int
+
+	// RetryBaseDelay is the delay before the first retry; it doubles on
+	// each subsequent attempt. 0 defaults to 100ms.
+	RetryBaseDelay time.Duration
+
+	hooks          Hooks
+	deliveries     *deliveryCache
+	deliveriesOnce sync.Once
+	pool           *workerPool
+	poolOnce       sync.Once
 }
 
 // Hook is the type of hook event being processed.
 type Hook struct {
-	Event string
-	Func  func(payload interface{})
+	Event   string
+	Func    reflect.Value
+	ArgType reflect.Type
 }
 
 // Hooks is a list of Hook.
 type Hooks []Hook
 
-var hooks Hooks
+var (
+	interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	contextType   = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+)
 
-// On is the primary registration mechanism for handlers. Handlers must accept
-// interface{} and process the resulting data (typically some form of
-// map[string]interface{}) in its handler after receiving the hook.
-func (s *Server) On(name string, handler func(payload interface{})) {
-	hooks = append(hooks, Hook{Event: name, Func: handler})
+// On is the primary registration mechanism for handlers. handler must be a
+// function accepting (context.Context, payload) and returning error, where
+// payload is either interface{}, in which case it receives the payload
+// decoded as map[string]interface{} as before, or one of the typed payload
+// structs (PushEvent, PullRequestEvent, IssueCommentEvent, ...), in which
+// case Handler parses the body into that struct before calling it.
+// Registrations are scoped to s, so multiple servers in one process do not
+// see each other's handlers.
+func (s *Server) On(name string, handler interface{}) {
+	v := reflect.ValueOf(handler)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 2 || t.In(0) != contextType || t.NumOut() != 1 || t.Out(0) != errorType {
+		panic("ghooks: handler must be a function accepting (context.Context, payload) and returning error")
+	}
+	s.hooks = append(s.hooks, Hook{Event: name, Func: v, ArgType: t.In(1)})
 }
 
-func emit(name string, payload interface{}) {
-	for _, v := range hooks {
-		if strings.EqualFold(v.Event, name) {
-			v.Func(payload)
+// dispatch runs every handler registered for name against body/legacyPayload,
+// either inline or via the worker pool depending on s.Workers and s.RunSync.
+func (s *Server) dispatch(ctx context.Context, name string, body []byte, legacyPayload interface{}) bool {
+	if s.RunSync || s.Workers <= 0 {
+		s.runHandlers(ctx, name, body, legacyPayload)
+		return true
+	}
+	return s.pooled().enqueue(job{event: name, body: body, legacyPayload: legacyPayload})
+}
+
+func (s *Server) runHandlers(ctx context.Context, name string, body []byte, legacyPayload interface{}) {
+	for _, h := range s.hooks {
+		if !strings.EqualFold(h.Event, name) {
+			continue
+		}
+
+		var arg reflect.Value
+		if h.ArgType == interfaceType {
+			if legacyPayload == nil {
+				arg = reflect.Zero(h.ArgType)
+			} else {
+				arg = reflect.ValueOf(legacyPayload)
+			}
+		} else {
+			ptr := reflect.New(h.ArgType)
+			if err := json.Unmarshal(body, ptr.Interface()); err != nil {
+				continue
+			}
+			arg = ptr.Elem()
+		}
+
+		s.invoke(ctx, h, arg, name)
+	}
+}
+
+// invoke calls h with arg, retrying on *TransientError up to s.MaxRetries
+// times with exponential backoff, then reports a final error to
+// s.ErrorHandler.
+func (s *Server) invoke(ctx context.Context, h Hook, arg reflect.Value, name string) {
+	delay := s.RetryBaseDelay
+	if delay <= 0 {
+		delay = 100 * time.Millisecond
+	}
+
+	for attempt := 0; ; attempt++ {
+		out := h.Func.Call([]reflect.Value{reflect.ValueOf(ctx), arg})
+		err, _ := out[0].Interface().(error)
+		if err == nil {
+			return
+		}
+
+		var transient *TransientError
+		if errors.As(err, &transient) && attempt < s.MaxRetries {
+			time.Sleep(delay)
+			delay *= 2
+			continue
+		}
+
+		if s.ErrorHandler != nil {
+			s.ErrorHandler(name, err)
 		}
+		return
 	}
 }
 
 // NewServer creates a new *Server.
 func NewServer() *Server {
-	return &Server{}
+	return &Server{Provider: GitHubProvider{}, deliveries: newDeliveryCache()}
 }
 
 // Handler is the primary handler returned by the server. You can leverage it
@@ -110,7 +300,12 @@ func (s *Server) Handler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	event := req.Header.Get("X-GitHub-Event")
+	provider := s.Provider
+	if provider == nil {
+		provider = GitHubProvider{}
+	}
+
+	event := provider.Event(req)
 
 	if event == "" {
 		http.Error(w, "Bad Request", http.StatusBadRequest)
@@ -129,16 +324,32 @@ func (s *Server) Handler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if s.Secret != "" {
-		signature := req.Header.Get("X-Hub-Signature")
-		if !s.isValidSignature(body, signature) {
+	if s.hasSecrets() {
+		if !s.isValidSignature(body, req) {
 			http.Error(w, "Bad Request", http.StatusBadRequest)
 			return
 		}
 	}
 
+	// Only check/record a delivery once it has passed signature validation
+	// above; otherwise an unauthenticated request with a guessed delivery
+	// ID could poison the cache and cause GitHub's subsequent authentic
+	// retry to be dropped. Recording itself is deferred until the
+	// delivery is actually accepted for processing below, so a 503 (full
+	// worker queue) leaves it unrecorded and the retry is not dropped.
+	var delivery string
+	if deliveryHeader := provider.DeliveryHeader(); deliveryHeader != "" && s.DedupSize > 0 {
+		if delivery = req.Header.Get(deliveryHeader); delivery != "" {
+			if s.deliveryCache().seen(delivery, s.DedupTTL) {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+		}
+	}
+
 	var payload interface{}
 	var decoder *json.Decoder
+	jsonBody := body
 
 	if strings.Contains(req.Header.Get("Content-Type"), "application/json") {
 
@@ -152,6 +363,7 @@ func (s *Server) Handler(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 		p := v.Get("payload")
+		jsonBody = []byte(p)
 		decoder = json.NewDecoder(strings.NewReader(p))
 	}
 
@@ -159,23 +371,22 @@ func (s *Server) Handler(w http.ResponseWriter, req *http.Request) {
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
-	emit(event, payload)
-	w.WriteHeader(http.StatusOK)
-}
 
-func (s *Server) isValidSignature(body []byte, signature string) bool {
-	if !strings.HasPrefix(signature, "sha1=") {
-		return false
-	}
+	accepted := s.dispatch(context.Background(), event, jsonBody, payload)
 
-	mac := hmac.New(sha1.New, []byte(s.Secret))
-	mac.Write(body)
-	actual := mac.Sum(nil)
+	if !accepted {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
 
-	expected, err := hex.DecodeString(signature[5:])
-	if err != nil {
-		return false
+	if delivery != "" {
+		s.deliveryCache().record(delivery, s.DedupSize, s.DedupTTL)
 	}
 
-	return hmac.Equal(actual, expected)
+	if s.RunSync || s.Workers <= 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
 }
+
@@ -0,0 +1,167 @@
+package ghooks
+
+import (
+	"context"
+	"crypto/sha1"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHandlerDedupsRepeatDeliveries(t *testing.T) {
+	s := NewServer()
+	s.DedupSize = 8
+
+	var calls int
+	s.On("push", func(ctx context.Context, payload interface{}) error {
+		calls++
+		return nil
+	})
+
+	do := func() int {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"ok":true}`))
+		req.Header.Set("X-GitHub-Event", "push")
+		req.Header.Set("X-GitHub-Delivery", "dup-1")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.Handler(w, req)
+		return w.Code
+	}
+
+	if code := do(); code != 200 {
+		t.Fatalf("first delivery: expected 200, got %d", code)
+	}
+	if code := do(); code != 200 {
+		t.Fatalf("repeat delivery: expected 200, got %d", code)
+	}
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+}
+
+func TestHandlerDoesNotDedupUnauthenticatedDeliveries(t *testing.T) {
+	s := NewServer()
+	s.DedupSize = 8
+	s.Secrets = []string{"shared-secret"}
+
+	var calls int
+	s.On("push", func(ctx context.Context, payload interface{}) error {
+		calls++
+		return nil
+	})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"ok":true}`))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-GitHub-Delivery", "dup-2")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hub-Signature", "sha1=bogus")
+	w := httptest.NewRecorder()
+	s.Handler(w, req)
+	if w.Code != 400 {
+		t.Fatalf("expected bad signature to be rejected with 400, got %d", w.Code)
+	}
+
+	body := `{"ok":true}`
+	sig := hexHMAC(sha1.New, "shared-secret", []byte(body))
+	req = httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-GitHub-Delivery", "dup-2")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hub-Signature", "sha1="+sig)
+	w = httptest.NewRecorder()
+	s.Handler(w, req)
+	if w.Code != 200 {
+		t.Fatalf("expected authentic retry to be accepted, got %d", w.Code)
+	}
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+}
+
+func TestHandlerDoesNotDedupRejectedDeliveries(t *testing.T) {
+	s := NewServer()
+	s.DedupSize = 8
+	s.Workers = 1
+	s.QueueSize = 1
+
+	block := make(chan struct{})
+
+	started := make(chan struct{})
+	var once sync.Once
+	var calls int32
+	s.On("push", func(ctx context.Context, payload interface{}) error {
+		atomic.AddInt32(&calls, 1)
+		once.Do(func() { close(started) })
+		<-block
+		return nil
+	})
+
+	post := func(delivery string) int {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"ok":true}`))
+		req.Header.Set("X-GitHub-Event", "push")
+		req.Header.Set("X-GitHub-Delivery", delivery)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.Handler(w, req)
+		return w.Code
+	}
+
+	if code := post("occupy-worker"); code != 202 {
+		t.Fatalf("expected first request to be accepted with 202, got %d", code)
+	}
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for worker to pick up the first job")
+	}
+
+	if code := post("fill-queue"); code != 202 {
+		t.Fatalf("expected second request to queue with 202, got %d", code)
+	}
+	if code := post("dup-4"); code != 503 {
+		t.Fatalf("expected third request to be rejected with 503 once the queue is full, got %d", code)
+	}
+
+	close(block)
+
+	// Wait for the queued "fill-queue" job to drain before retrying,
+	// otherwise the retry can race the worker and see the queue still
+	// full.
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&calls) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for queued job to drain, ran %d times", calls)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if code := post("dup-4"); code != 202 {
+		t.Fatalf("expected retry of the rejected delivery to be accepted, got %d", code)
+	}
+
+	deadline = time.Now().Add(time.Second)
+	for atomic.LoadInt32(&calls) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for all deliveries to run, ran %d times", calls)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHandlerDedupWithoutNewServer(t *testing.T) {
+	s := &Server{DedupSize: 8}
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"ok":true}`))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-GitHub-Delivery", "dup-3")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.Handler(w, req)
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+}
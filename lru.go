@@ -0,0 +1,94 @@
+package ghooks
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// deliveryCache is a size- and TTL-bounded LRU used to recognize webhook
+// deliveries GitHub has already retried, so Handler can short-circuit them
+// with 200 OK without invoking handlers a second time.
+type deliveryCache struct {
+	mu       sync.Mutex
+	ll       *list.List
+	elements map[string]*list.Element
+}
+
+type deliveryEntry struct {
+	id      string
+	expires time.Time
+}
+
+func newDeliveryCache() *deliveryCache {
+	return &deliveryCache{
+		ll:       list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+// deliveryCache lazily initializes s's delivery dedup cache on first use, so
+// a Server constructed as a struct literal (rather than via NewServer) is
+// still safe to set DedupSize on.
+func (s *Server) deliveryCache() *deliveryCache {
+	s.deliveriesOnce.Do(func() {
+		if s.deliveries == nil {
+			s.deliveries = newDeliveryCache()
+		}
+	})
+	return s.deliveries
+}
+
+// seen reports whether id has already been recorded and not yet expired. It
+// does not record id itself; call record once the delivery has actually been
+// accepted for processing, so a delivery that's rejected or never dispatched
+// (e.g. a full worker queue) remains unrecorded and a subsequent retry is not
+// dropped.
+func (c *deliveryCache) seen(id string, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.elements[id]
+	if !ok {
+		return false
+	}
+
+	entry := el.Value.(*deliveryEntry)
+	if ttl > 0 && !entry.expires.After(time.Now()) {
+		c.ll.Remove(el)
+		delete(c.elements, id)
+		return false
+	}
+
+	c.ll.MoveToFront(el)
+	return true
+}
+
+// record marks id as seen. size bounds how many deliveries are remembered at
+// once (0 means unbounded); ttl bounds how long an entry counts as a repeat
+// (0 means entries only expire via size-based eviction).
+func (c *deliveryCache) record(id string, size int, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.elements[id]; ok {
+		c.ll.Remove(el)
+		delete(c.elements, id)
+	}
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	el := c.ll.PushFront(&deliveryEntry{id: id, expires: expires})
+	c.elements[id] = el
+
+	for size > 0 && c.ll.Len() > size {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			break
+		}
+		c.ll.Remove(oldest)
+		delete(c.elements, oldest.Value.(*deliveryEntry).id)
+	}
+}
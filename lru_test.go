@@ -0,0 +1,55 @@
+package ghooks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeliveryCacheSeen(t *testing.T) {
+	c := newDeliveryCache()
+
+	if c.seen("a", 0) {
+		t.Fatal("first sighting of \"a\" should not be reported as seen")
+	}
+	c.record("a", 0, 0)
+	if !c.seen("a", 0) {
+		t.Fatal("second sighting of \"a\" should be reported as seen")
+	}
+}
+
+func TestDeliveryCacheEvictsBySize(t *testing.T) {
+	c := newDeliveryCache()
+
+	c.record("a", 2, 0)
+	c.record("b", 2, 0)
+	c.record("c", 2, 0) // evicts "a"
+
+	if !c.seen("b", 0) {
+		t.Fatal("\"b\" should still be cached")
+	}
+	if c.seen("a", 0) {
+		t.Fatal("\"a\" should have been evicted once the cache exceeded size 2")
+	}
+}
+
+func TestDeliveryCacheExpiresByTTL(t *testing.T) {
+	c := newDeliveryCache()
+
+	c.record("a", 0, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	if c.seen("a", time.Nanosecond) {
+		t.Fatal("expired entry should not be reported as seen")
+	}
+}
+
+func TestDeliveryCacheDoesNotRecordUnlessCommitted(t *testing.T) {
+	c := newDeliveryCache()
+
+	if c.seen("a", 0) {
+		t.Fatal("unrecorded delivery should not be reported as seen")
+	}
+	if c.seen("a", 0) {
+		t.Fatal("seen must not record as a side effect")
+	}
+}
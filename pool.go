@@ -0,0 +1,61 @@
+package ghooks
+
+import (
+	"context"
+)
+
+// job is a queued event awaiting delivery to its handlers.
+type job struct {
+	event         string
+	body          []byte
+	legacyPayload interface{}
+}
+
+// workerPool drains queued jobs with a fixed number of goroutines, giving
+// Handler bounded backpressure: once the queue is full, enqueue reports
+// false instead of growing without limit.
+type workerPool struct {
+	jobs chan job
+}
+
+func newWorkerPool(s *Server) *workerPool {
+	workers := s.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	queueSize := s.QueueSize
+	if queueSize <= 0 {
+		queueSize = workers
+	}
+
+	p := &workerPool{jobs: make(chan job, queueSize)}
+	for i := 0; i < workers; i++ {
+		go p.run(s)
+	}
+	return p
+}
+
+func (p *workerPool) run(s *Server) {
+	for j := range p.jobs {
+		s.runHandlers(context.Background(), j.event, j.body, j.legacyPayload)
+	}
+}
+
+// enqueue reports whether j was accepted; it returns false if the pool's
+// queue is full.
+func (p *workerPool) enqueue(j job) bool {
+	select {
+	case p.jobs <- j:
+		return true
+	default:
+		return false
+	}
+}
+
+// pooled lazily starts s's worker pool on first use.
+func (s *Server) pooled() *workerPool {
+	s.poolOnce.Do(func() {
+		s.pool = newWorkerPool(s)
+	})
+	return s.pool
+}
@@ -0,0 +1,105 @@
+package ghooks
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInvokeRetriesTransientErrors(t *testing.T) {
+	s := NewServer()
+	s.MaxRetries = 2
+	s.RetryBaseDelay = time.Millisecond
+
+	var attempts int
+	var errHandlerCalls int
+	s.ErrorHandler = func(event string, err error) { errHandlerCalls++ }
+
+	s.On("push", func(ctx context.Context, payload interface{}) error {
+		attempts++
+		if attempts <= 2 {
+			return &TransientError{Err: errors.New("not yet")}
+		}
+		return nil
+	})
+
+	s.runHandlers(context.Background(), "push", []byte(`{}`), map[string]interface{}{})
+
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts (1 + 2 retries), got %d", attempts)
+	}
+	if errHandlerCalls != 0 {
+		t.Fatalf("expected ErrorHandler not to be called once the handler eventually succeeds, got %d calls", errHandlerCalls)
+	}
+}
+
+func TestInvokeGivesUpAfterMaxRetries(t *testing.T) {
+	s := NewServer()
+	s.MaxRetries = 1
+	s.RetryBaseDelay = time.Millisecond
+
+	var attempts int
+	var lastErr error
+	s.ErrorHandler = func(event string, err error) { lastErr = err }
+
+	s.On("push", func(ctx context.Context, payload interface{}) error {
+		attempts++
+		return &TransientError{Err: errors.New("always fails")}
+	})
+
+	s.runHandlers(context.Background(), "push", []byte(`{}`), map[string]interface{}{})
+
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts (1 + 1 retry), got %d", attempts)
+	}
+	if lastErr == nil {
+		t.Fatal("expected ErrorHandler to be called once retries are exhausted")
+	}
+}
+
+func TestHandlerAsyncBackpressure(t *testing.T) {
+	s := NewServer()
+	s.Workers = 1
+	s.QueueSize = 1
+
+	block := make(chan struct{})
+	defer close(block)
+
+	started := make(chan struct{})
+	var once sync.Once
+	s.On("push", func(ctx context.Context, payload interface{}) error {
+		once.Do(func() { close(started) })
+		<-block
+		return nil
+	})
+
+	post := func() int {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{}`))
+		req.Header.Set("X-GitHub-Event", "push")
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.Handler(w, req)
+		return w.Code
+	}
+
+	if code := post(); code != 202 {
+		t.Fatalf("expected first request to be accepted with 202, got %d", code)
+	}
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for worker to pick up the first job")
+	}
+
+	if code := post(); code != 202 {
+		t.Fatalf("expected second request to queue with 202, got %d", code)
+	}
+	if code := post(); code != 503 {
+		t.Fatalf("expected third request to be rejected with 503 once the queue is full, got %d", code)
+	}
+}
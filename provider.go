@@ -0,0 +1,120 @@
+package ghooks
+
+import "net/http"
+
+// Provider identifies the forge a webhook request originated from: which
+// header carries the event name, and which carries the request's unique
+// delivery ID (if any). Server defaults to GitHub; set Server.Provider to
+// multiplex webhooks from other forges on the same server.
+type Provider interface {
+	// EventHeader is the header holding the event name, e.g.
+	// "X-GitHub-Event".
+	EventHeader() string
+
+	// DeliveryHeader is the header holding a per-delivery identifier, or
+	// "" if the forge does not send one.
+	DeliveryHeader() string
+
+	// Event extracts the event name from the request.
+	Event(req *http.Request) string
+}
+
+// GitHubProvider is the Provider for GitHub webhooks.
+type GitHubProvider struct{}
+
+// EventHeader implements Provider.
+func (GitHubProvider) EventHeader() string { return "X-GitHub-Event" }
+
+// DeliveryHeader implements Provider.
+func (GitHubProvider) DeliveryHeader() string { return "X-GitHub-Delivery" }
+
+// Event implements Provider.
+func (p GitHubProvider) Event(req *http.Request) string {
+	return req.Header.Get(p.EventHeader())
+}
+
+// gitlabEventNames maps GitLab's "X-Gitlab-Event" values to the GitHub-style
+// event names ghooks handlers are registered under, so the same handler set
+// can serve both forges. Values with no entry here are passed through
+// unchanged.
+var gitlabEventNames = map[string]string{
+	"Push Hook":          "push",
+	"Tag Push Hook":      "tag_push",
+	"Issue Hook":         "issues",
+	"Note Hook":          "issue_comment",
+	"Merge Request Hook": "pull_request",
+	"Wiki Page Hook":     "wiki_page",
+	"Pipeline Hook":      "pipeline",
+	"Job Hook":           "job",
+}
+
+// GitLabProvider is the Provider for GitLab webhooks.
+type GitLabProvider struct{}
+
+// EventHeader implements Provider.
+func (GitLabProvider) EventHeader() string { return "X-Gitlab-Event" }
+
+// DeliveryHeader implements Provider.
+func (GitLabProvider) DeliveryHeader() string { return "" }
+
+// Event implements Provider, normalizing GitLab's event names (e.g. "Push
+// Hook") to their GitHub-style equivalent (e.g. "push").
+func (p GitLabProvider) Event(req *http.Request) string {
+	return normalizeEvent(req.Header.Get(p.EventHeader()), gitlabEventNames)
+}
+
+// bitbucketEventNames maps Bitbucket's "X-Event-Key" values to the
+// GitHub-style event names ghooks handlers are registered under. Values
+// with no entry here are passed through unchanged.
+var bitbucketEventNames = map[string]string{
+	"repo:push":                   "push",
+	"pullrequest:created":         "pull_request",
+	"pullrequest:updated":         "pull_request",
+	"pullrequest:fulfilled":       "pull_request",
+	"pullrequest:rejected":        "pull_request",
+	"pullrequest:comment_created": "issue_comment",
+	"issue:created":               "issues",
+	"issue:comment_created":       "issue_comment",
+}
+
+// BitbucketProvider is the Provider for Bitbucket webhooks.
+type BitbucketProvider struct{}
+
+// EventHeader implements Provider.
+func (BitbucketProvider) EventHeader() string { return "X-Event-Key" }
+
+// DeliveryHeader implements Provider.
+func (BitbucketProvider) DeliveryHeader() string { return "X-Request-UUID" }
+
+// Event implements Provider, normalizing Bitbucket's event keys (e.g.
+// "repo:push") to their GitHub-style equivalent (e.g. "push").
+func (p BitbucketProvider) Event(req *http.Request) string {
+	return normalizeEvent(req.Header.Get(p.EventHeader()), bitbucketEventNames)
+}
+
+// normalizeEvent maps a provider's raw event name to its GitHub-style
+// equivalent via table, passing it through unchanged if table has no entry
+// for it so handlers can still register for events ghooks doesn't know to
+// map yet.
+func normalizeEvent(raw string, table map[string]string) string {
+	if mapped, ok := table[raw]; ok {
+		return mapped
+	}
+	return raw
+}
+
+// GiteaProvider is the Provider for Gogs/Gitea webhooks. It reads the event
+// name from X-Gitea-Event and the delivery ID from X-Gitea-Delivery, and
+// passes the event name through without normalization.
+type GiteaProvider struct{}
+
+// EventHeader implements Provider.
+func (GiteaProvider) EventHeader() string { return "X-Gitea-Event" }
+
+// DeliveryHeader implements Provider.
+func (GiteaProvider) DeliveryHeader() string { return "X-Gitea-Delivery" }
+
+// Event implements Provider.
+func (p GiteaProvider) Event(req *http.Request) string {
+	return req.Header.Get(p.EventHeader())
+}
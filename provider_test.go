@@ -0,0 +1,44 @@
+package ghooks
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeEvent(t *testing.T) {
+	cases := []struct {
+		raw   string
+		table map[string]string
+		want  string
+	}{
+		{"Push Hook", gitlabEventNames, "push"},
+		{"Merge Request Hook", gitlabEventNames, "pull_request"},
+		{"repo:push", bitbucketEventNames, "push"},
+		{"pullrequest:created", bitbucketEventNames, "pull_request"},
+		{"Some Unknown Hook", gitlabEventNames, "Some Unknown Hook"},
+	}
+
+	for _, c := range cases {
+		if got := normalizeEvent(c.raw, c.table); got != c.want {
+			t.Errorf("normalizeEvent(%q) = %q, want %q", c.raw, got, c.want)
+		}
+	}
+}
+
+func TestGitLabProviderEventNormalizesHeader(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-Gitlab-Event", "Push Hook")
+
+	if got := (GitLabProvider{}).Event(req); got != "push" {
+		t.Fatalf("expected GitLabProvider to normalize \"Push Hook\" to \"push\", got %q", got)
+	}
+}
+
+func TestBitbucketProviderEventNormalizesHeader(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-Event-Key", "repo:push")
+
+	if got := (BitbucketProvider{}).Event(req); got != "push" {
+		t.Fatalf("expected BitbucketProvider to normalize \"repo:push\" to \"push\", got %q", got)
+	}
+}
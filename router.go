@@ -0,0 +1,119 @@
+package ghooks
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+)
+
+// Router is an http.Handler that dispatches webhook requests to a plain
+// http.Handler per event name, so ghooks composes with ordinary net/http
+// middleware (logging, metrics, tracing, rate-limiting) instead of owning
+// the whole request pipeline. It exists alongside Server/On for callers who
+// want to wire routing and auth themselves.
+type Router struct {
+	// Provider identifies the event header to dispatch on. Defaults to
+	// GitHubProvider.
+	Provider Provider
+
+	// NotFoundHandler is called when no route matches the request's
+	// event. Defaults to http.NotFound.
+	NotFoundHandler http.Handler
+
+	// UnauthorizedHandler is called by routes registered through
+	// Authorize when signature validation fails. Defaults to
+	// http.Error(w, "Unauthorized", http.StatusUnauthorized).
+	UnauthorizedHandler http.Handler
+
+	routes map[string]http.Handler
+}
+
+// NewRouter creates a *Router ready to have routes registered on it.
+func NewRouter() *Router {
+	return &Router{Provider: GitHubProvider{}, routes: make(map[string]http.Handler)}
+}
+
+// Handle registers h to serve requests for the named event.
+func (r *Router) Handle(event string, h http.Handler) {
+	if r.routes == nil {
+		r.routes = make(map[string]http.Handler)
+	}
+	r.routes[event] = h
+}
+
+// HandleFunc registers h to serve requests for the named event.
+func (r *Router) HandleFunc(event string, h func(w http.ResponseWriter, req *http.Request)) {
+	r.Handle(event, http.HandlerFunc(h))
+}
+
+// Authorize registers h to serve requests for the named event, rejecting
+// any request whose signature does not validate against one of secrets
+// before h is called.
+func (r *Router) Authorize(event string, h http.Handler, secrets ...string) {
+	r.Handle(event, authorize(h, r.unauthorizedHandler(), secrets))
+}
+
+func (r *Router) unauthorizedHandler() http.Handler {
+	if r.UnauthorizedHandler != nil {
+		return r.UnauthorizedHandler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// ServeHTTP implements http.Handler, dispatching to the handler registered
+// for the request's event header.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	provider := r.Provider
+	if provider == nil {
+		provider = GitHubProvider{}
+	}
+
+	event := provider.Event(req)
+	h, ok := r.routes[event]
+	if !ok {
+		if r.NotFoundHandler != nil {
+			r.NotFoundHandler.ServeHTTP(w, req)
+			return
+		}
+		http.NotFound(w, req)
+		return
+	}
+
+	h.ServeHTTP(w, req)
+}
+
+// Authorize wraps h so that requests are only forwarded to it once their
+// signature validates against one of secrets; others get a 401
+// Unauthorized. It lets ghooks-style signature checking compose with any
+// http.Handler, independent of Router or Server.
+func Authorize(h http.Handler, secrets ...string) http.Handler {
+	return authorize(h, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	}), secrets)
+}
+
+func authorize(h, unauthorized http.Handler, secrets []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Body == nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		defer req.Body.Close()
+
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		if !validSignature(body, req, secrets) {
+			unauthorized.ServeHTTP(w, req)
+			return
+		}
+
+		h.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,144 @@
+package ghooks
+
+import (
+	"crypto/sha1"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouterDispatchesByEvent(t *testing.T) {
+	r := NewRouter()
+
+	var pushed, pulled bool
+	r.HandleFunc("push", func(w http.ResponseWriter, req *http.Request) {
+		pushed = true
+		w.WriteHeader(http.StatusOK)
+	})
+	r.Handle("pull_request", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		pulled = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{}`))
+	req.Header.Set("X-GitHub-Event", "push")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if !pushed || pulled {
+		t.Fatalf("expected only the push route to run, pushed=%v pulled=%v", pushed, pulled)
+	}
+}
+
+func TestRouterNotFoundHandler(t *testing.T) {
+	r := NewRouter()
+	r.HandleFunc("push", func(w http.ResponseWriter, req *http.Request) {
+		t.Fatal("handler for unregistered event should not run")
+	})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{}`))
+	req.Header.Set("X-GitHub-Event", "issues")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected default NotFoundHandler to reply 404, got %d", w.Code)
+	}
+}
+
+func TestRouterCustomNotFoundHandler(t *testing.T) {
+	r := NewRouter()
+
+	var called bool
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{}`))
+	req.Header.Set("X-GitHub-Event", "issues")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if !called || w.Code != http.StatusTeapot {
+		t.Fatalf("expected custom NotFoundHandler to run, called=%v code=%d", called, w.Code)
+	}
+}
+
+func TestRouterAuthorizeRejectsBadSignature(t *testing.T) {
+	r := NewRouter()
+
+	var called bool
+	r.Authorize("push", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}), "s3cr3t")
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"ok":true}`))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-Hub-Signature", "sha1=deadbeef")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected default UnauthorizedHandler to reply 401, got %d", w.Code)
+	}
+	if called {
+		t.Fatal("handler must not run when the signature fails to validate")
+	}
+}
+
+func TestRouterAuthorizeCustomUnauthorizedHandler(t *testing.T) {
+	r := NewRouter()
+
+	var called bool
+	r.UnauthorizedHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusForbidden)
+	})
+	r.Authorize("push", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t.Fatal("handler must not run when the signature fails to validate")
+	}), "s3cr3t")
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"ok":true}`))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-Hub-Signature", "sha1=deadbeef")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if !called || w.Code != http.StatusForbidden {
+		t.Fatalf("expected custom UnauthorizedHandler to run, called=%v code=%d", called, w.Code)
+	}
+}
+
+func TestAuthorizeAllowsValidSignatureAndRestoresBody(t *testing.T) {
+	body := `{"ok":true}`
+	sig := hexHMAC(sha1.New, "s3cr3t", []byte(body))
+
+	var gotBody string
+	h := Authorize(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Fatalf("downstream handler failed to read body: %v", err)
+		}
+		gotBody = string(b)
+		w.WriteHeader(http.StatusOK)
+	}), "s3cr3t")
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("X-Hub-Signature", "sha1="+sig)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected valid signature to pass through, got %d", w.Code)
+	}
+	if gotBody != body {
+		t.Fatalf("expected authorize to restore req.Body for the downstream handler, got %q", gotBody)
+	}
+}
@@ -0,0 +1,84 @@
+package ghooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"net/http"
+)
+
+// signatureScheme pairs a signature header with the prefix and hash
+// algorithm used to validate it. Entries are checked strongest first so
+// that, when a request carries more than one signature header, ghooks
+// validates against the strongest one present rather than silently
+// accepting a weaker one.
+type signatureScheme struct {
+	header string
+	prefix string
+	new    func() hash.Hash
+}
+
+var signatureSchemes = []signatureScheme{
+	{header: "X-Hub-Signature-512", prefix: "sha512=", new: sha512.New},
+	{header: "X-Hub-Signature-256", prefix: "sha256=", new: sha256.New},
+	{header: "X-Hub-Signature", prefix: "sha1=", new: sha1.New},
+}
+
+// secrets returns the configured HMAC secrets, folding the deprecated
+// Secret field in alongside Secrets.
+func (s *Server) secrets() []string {
+	if s.Secret == "" {
+		return s.Secrets
+	}
+	return append([]string{s.Secret}, s.Secrets...)
+}
+
+// hasSecrets reports whether s has any secret configured to validate
+// signatures against.
+func (s *Server) hasSecrets() bool {
+	return s.Secret != "" || len(s.Secrets) > 0
+}
+
+// isValidSignature validates body against whichever of req's signature
+// headers is strongest, accepting the request if it matches any secret
+// configured on s.
+func (s *Server) isValidSignature(body []byte, req *http.Request) bool {
+	return validSignature(body, req, s.secrets())
+}
+
+// validSignature validates body against whichever of req's signature
+// headers is strongest, accepting the request if it matches any configured
+// secret. A present signature header with a malformed prefix or length, or
+// one that matches no configured secret, is rejected; it never falls back
+// to a weaker header once a signature header has been found.
+func validSignature(body []byte, req *http.Request, secrets []string) bool {
+	for _, scheme := range signatureSchemes {
+		signature := req.Header.Get(scheme.header)
+		if signature == "" {
+			continue
+		}
+
+		if len(signature) <= len(scheme.prefix) || signature[:len(scheme.prefix)] != scheme.prefix {
+			return false
+		}
+
+		expected, err := hex.DecodeString(signature[len(scheme.prefix):])
+		if err != nil {
+			return false
+		}
+
+		for _, secret := range secrets {
+			mac := hmac.New(scheme.new, []byte(secret))
+			mac.Write(body)
+			if hmac.Equal(mac.Sum(nil), expected) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return false
+}
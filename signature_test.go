@@ -0,0 +1,122 @@
+package ghooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func hexHMAC(newHash func() hash.Hash, secret string, body []byte) string {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidSignatureSHA1(t *testing.T) {
+	body := []byte(`{"ok":true}`)
+	sig := hexHMAC(sha1.New, "s3cr3t", body)
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-Hub-Signature", "sha1="+sig)
+
+	if !validSignature(body, req, []string{"s3cr3t"}) {
+		t.Fatal("expected valid sha1 signature to validate")
+	}
+}
+
+func TestValidSignaturePrefersStrongestHeader(t *testing.T) {
+	body := []byte(`{"ok":true}`)
+	sig256 := hexHMAC(sha256.New, "s3cr3t", body)
+
+	req := httptest.NewRequest("POST", "/", nil)
+	// A bogus sha1 header should be ignored in favor of the valid sha256 one.
+	req.Header.Set("X-Hub-Signature", "sha1=deadbeef")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+sig256)
+
+	if !validSignature(body, req, []string{"s3cr3t"}) {
+		t.Fatal("expected sha256 header to be validated instead of falling back to sha1")
+	}
+}
+
+func TestValidSignatureSHA512(t *testing.T) {
+	body := []byte(`{"ok":true}`)
+	sig := hexHMAC(sha512.New, "s3cr3t", body)
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-Hub-Signature-512", "sha512="+sig)
+
+	if !validSignature(body, req, []string{"s3cr3t"}) {
+		t.Fatal("expected valid sha512 signature to validate")
+	}
+}
+
+func TestValidSignatureRotatingSecrets(t *testing.T) {
+	body := []byte(`{"ok":true}`)
+	sig := hexHMAC(sha256.New, "new-secret", body)
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-Hub-Signature-256", "sha256="+sig)
+
+	if !validSignature(body, req, []string{"old-secret", "new-secret"}) {
+		t.Fatal("expected request to validate against any configured secret")
+	}
+}
+
+func TestValidSignatureWrongSecret(t *testing.T) {
+	body := []byte(`{"ok":true}`)
+	sig := hexHMAC(sha256.New, "s3cr3t", body)
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-Hub-Signature-256", "sha256="+sig)
+
+	if validSignature(body, req, []string{"wrong-secret"}) {
+		t.Fatal("expected signature not matching any configured secret to be rejected")
+	}
+}
+
+func TestValidSignatureMalformedPrefix(t *testing.T) {
+	body := []byte(`{"ok":true}`)
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-Hub-Signature-256", "not-a-valid-prefix")
+
+	if validSignature(body, req, []string{"s3cr3t"}) {
+		t.Fatal("expected malformed signature prefix to be rejected")
+	}
+}
+
+func TestValidSignatureMalformedHex(t *testing.T) {
+	body := []byte(`{"ok":true}`)
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-Hub-Signature-256", "sha256=zz")
+
+	if validSignature(body, req, []string{"s3cr3t"}) {
+		t.Fatal("expected non-hex signature to be rejected")
+	}
+}
+
+func TestValidSignatureNoHeader(t *testing.T) {
+	body := []byte(`{"ok":true}`)
+	req := httptest.NewRequest("POST", "/", nil)
+
+	if validSignature(body, req, []string{"s3cr3t"}) {
+		t.Fatal("expected a request with no signature header to be rejected")
+	}
+}
+
+func TestServerSecretsFoldsLegacySecret(t *testing.T) {
+	s := &Server{Secret: "legacy", Secrets: []string{"current"}}
+	secrets := s.secrets()
+
+	joined := strings.Join(secrets, ",")
+	if len(secrets) != 2 || !strings.Contains(joined, "legacy") || !strings.Contains(joined, "current") {
+		t.Fatalf("expected both legacy Secret and Secrets to be considered, got %v", secrets)
+	}
+}